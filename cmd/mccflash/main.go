@@ -0,0 +1,52 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/gotmc/mccdaq/usb1608fsplus"
+	"github.com/gotmc/mccdaq/usb1608fsplus/dfu"
+)
+
+func main() {
+	var (
+		firmwareFlag = flag.String("firmware", "", "Path to the raw binary MCC firmware image to flash; its bytes are downloaded as-is.")
+	)
+	flag.Parse()
+	if *firmwareFlag == "" {
+		log.Fatal("must provide -firmware")
+	}
+
+	firmwareFile, err := os.Open(*firmwareFlag)
+	if err != nil {
+		log.Fatalf("error opening firmware image: %s", err)
+	}
+	defer firmwareFile.Close()
+
+	// Initialize the USB Context
+	ctx, err := usb1608fsplus.Init()
+	if err != nil {
+		log.Fatal("Couldn't create USB context. Ending now.")
+	}
+	defer ctx.Exit()
+
+	daq, err := usb1608fsplus.GetFirstDevice(ctx)
+	if err != nil {
+		log.Fatalf("Couldn't find a USB-1608FS-Plus: %s", err)
+	}
+
+	log.Println("Flashing firmware; do not unplug the device...")
+	err = dfu.Flash(daq, firmwareFile, func(pct float64) {
+		log.Printf("progress: %.1f%%", pct)
+	})
+	if err != nil {
+		log.Fatalf("Error flashing firmware: %s", err)
+	}
+	log.Println("Firmware flashed successfully.")
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	maxChannels  = 8
+	bytesPerWord = 2
+	maxFrequency = 500000
+
+	bulkEndpointIn = 0x86
+)
+
+// statusScanOverrun is the scan-overrun bit of the status word returned by
+// commandGetStatus, per the vendor protocol (bit 1, 0x1<<1, is
+// scan-running; bit 2 is the overrun flag checked here).
+const statusScanOverrun = 0x1 << 2
+
+// AnalogInput models the analog input subsystem of the USB-1608FS-Plus,
+// including the per-channel configuration and the state of any scan in
+// progress.
+type AnalogInput struct {
+	daq       *DAQ
+	Channels  [maxChannels]Channel `json:"channels"`
+	Frequency float64              `json:"frequency"`
+
+	// droppedBuffers and underruns are updated atomically by StreamAsync.
+	droppedBuffers uint64
+	underruns      uint64
+}
+
+// NewAnalogInput creates an AnalogInput for the given DAQ with all channels
+// enabled and defaulting to the +/-10V range.
+func (daq *DAQ) NewAnalogInput() (*AnalogInput, error) {
+	ai := &AnalogInput{daq: daq}
+	for i := range ai.Channels {
+		ai.Channels[i] = Channel{
+			Description: fmt.Sprintf("Channel %d", i),
+			Enabled:     true,
+			Range:       Range10V,
+		}
+	}
+	return ai, nil
+}
+
+// enabledChannels returns a byte as an 8-bit flag identifying the enabled
+// analog input channels.
+func (ai *AnalogInput) enabledChannels() byte {
+	var enabled byte
+	for i, ch := range ai.Channels {
+		if ch.Enabled {
+			enabled |= 0x1 << uint(i)
+		}
+	}
+	return enabled
+}
+
+// NumEnabledChannels returns the number of analog input channels currently
+// enabled for scanning.
+func (ai *AnalogInput) NumEnabledChannels() int {
+	n := 0
+	for _, ch := range ai.Channels {
+		if ch.Enabled {
+			n++
+		}
+	}
+	return n
+}
+
+// StartScan starts a continuous analog input scan. A count of 0 scans
+// continuously until StopScan is called.
+//
+// The internal pacer rate is set by a 32-bit timer running at a base rate
+// of 40 MHz, controlled by the pacer period:
+//
+//	pacer_period = (40 MHz / frequency) - 1
+func (ai *AnalogInput) StartScan(count uint32) error {
+	if err := ai.StopScan(); err != nil {
+		return fmt.Errorf("error stopping analog input scan prior to starting a new scan: %s", err)
+	}
+	if err := ai.ClearScanBuffer(); err != nil {
+		return fmt.Errorf("error clearing scan buffer prior to starting a new scan: %s", err)
+	}
+	t := newTransfer(ai.daq, commandAnalogStartScan)
+	t.WriteUint32LE(count)
+	t.WriteUint32LE(pacerPeriod(ai.Frequency))
+	t.WriteByte(ai.enabledChannels())
+	t.WriteByte(0x0) // reserved options byte
+	if _, err := t.Execute(0); err != nil {
+		return fmt.Errorf("error starting analog input scan: %s", err)
+	}
+	return nil
+}
+
+// pacerPeriod converts a sample frequency into the 32-bit pacer period
+// value expected by the device's 40 MHz internal timer.
+func pacerPeriod(frequency float64) uint32 {
+	if frequency <= 0 {
+		return 0
+	}
+	if frequency > maxFrequency {
+		frequency = maxFrequency
+	}
+	return uint32(math.Round(40e6/frequency) - 1)
+}
+
+// StopScan halts any analog input scan in progress.
+func (ai *AnalogInput) StopScan() error {
+	if _, err := ai.daq.sendCommand(commandAnalogStopScan, nil); err != nil {
+		return fmt.Errorf("error stopping analog input scan: %s", err)
+	}
+	return nil
+}
+
+// ClearScanBuffer clears the device's internal analog input scan FIFO
+// buffer.
+func (ai *AnalogInput) ClearScanBuffer() error {
+	if _, err := ai.daq.sendCommand(commandAnalogClearBuffer, nil); err != nil {
+		return fmt.Errorf("error clearing analog input scan buffer: %s", err)
+	}
+	return nil
+}
+
+// SetScanRanges writes the configured input range for each channel to the
+// device.
+func (ai *AnalogInput) SetScanRanges() error {
+	t := newTransfer(ai.daq, commandAnalogConfig)
+	for _, ch := range ai.Channels {
+		t.WriteByte(byte(ch.Range))
+	}
+	if _, err := t.Execute(0); err != nil {
+		return fmt.Errorf("error setting analog input scan ranges: %s", err)
+	}
+	return nil
+}
+
+// ScanRanges reads back the input range configured for each channel from
+// the device.
+func (ai *AnalogInput) ScanRanges() ([]byte, error) {
+	ranges, err := newTransfer(ai.daq, commandAnalogConfig).Execute(maxChannels)
+	if err != nil {
+		return nil, fmt.Errorf("error reading analog input scan ranges: %s", err)
+	}
+	return ranges, nil
+}
+
+// status reads the device's current scan status word.
+func (ai *AnalogInput) status() (uint16, error) {
+	data, err := newTransfer(ai.daq, commandGetStatus).Execute(2)
+	if err != nil {
+		return 0, fmt.Errorf("error reading analog input status: %s", err)
+	}
+	return binary.LittleEndian.Uint16(data), nil
+}
+
+// ReadScan reads numScans scans worth of raw, little-endian sample words
+// from the device's bulk endpoint. The returned slice holds up to
+// numScans * NumEnabledChannels() * 2 bytes; it is shorter when the bulk
+// transfer returns fewer bytes.
+func (ai *AnalogInput) ReadScan(numScans int) ([]byte, error) {
+	numBytes := numScans * ai.NumEnabledChannels() * bytesPerWord
+	data := make([]byte, numBytes)
+	bytesRead, err := ai.daq.DeviceHandle.BulkTransfer(bulkEndpointIn, data, numBytes, ai.daq.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error reading analog input scan: %s", err)
+	}
+	return data[:bytesRead], nil
+}
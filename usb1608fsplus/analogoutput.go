@@ -0,0 +1,60 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import "fmt"
+
+// AnalogOutput would model the analog output subsystem found on some
+// adjacent MCC USB DAQ families.
+//
+// The USB-1608FS-Plus itself has no DAC and no analog output FIFO — its
+// vendor protocol (see commands.go) defines only digital I/O, analog
+// input, memory, and miscellaneous commands. AnalogOutput is kept here,
+// with JSON tags matching AnalogInput's, so an analog_config.json written
+// for a board that does have output channels doesn't fail to decode; every
+// method returns an error rather than silently pretending to write a
+// voltage the hardware can't produce.
+//
+// NOTE for whoever owns this backlog item: the request asked for an
+// AnalogOutput subsystem mirroring AnalogInput's SetVoltage/WriteScan/
+// StartScan/StopScan API against real hardware. What's here is a stub that
+// satisfies the type/method shape but can't do that, because this board
+// has no DAC to drive. Flagging the mismatch rather than closing this out
+// as delivered — please confirm the stub is what's wanted before treating
+// this item as done.
+type AnalogOutput struct {
+	Channels [2]Channel `json:"channels"`
+}
+
+// errNoAnalogOutput is returned by every AnalogOutput method, since the
+// USB-1608FS-Plus has no analog output hardware to drive.
+var errNoAnalogOutput = fmt.Errorf("USB-1608FS-Plus has no analog output channels")
+
+// NewAnalogOutput reports that the USB-1608FS-Plus has no analog output
+// subsystem.
+func (daq *DAQ) NewAnalogOutput() (*AnalogOutput, error) {
+	return nil, errNoAnalogOutput
+}
+
+// SetVoltage always returns an error; see AnalogOutput.
+func (ao *AnalogOutput) SetVoltage(channel int, v float64) error {
+	return errNoAnalogOutput
+}
+
+// WriteScan always returns an error; see AnalogOutput.
+func (ao *AnalogOutput) WriteScan(samples []int16, rate float64) error {
+	return errNoAnalogOutput
+}
+
+// StartScan always returns an error; see AnalogOutput.
+func (ao *AnalogOutput) StartScan() error {
+	return errNoAnalogOutput
+}
+
+// StopScan always returns an error; see AnalogOutput.
+func (ao *AnalogOutput) StopScan() error {
+	return errNoAnalogOutput
+}
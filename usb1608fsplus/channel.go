@@ -0,0 +1,68 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// converter is the zero-volt code for the 16-bit, bipolar analog-to-digital
+// converter used by the USB-1608FS-Plus.
+const converter = 32768
+
+// Range is a byte value used by the DAQ to determine the input voltage
+// range for an analog input channel.
+type Range byte
+
+// Available input voltage ranges.
+const (
+	Range10V Range = 0x0 // +/-10V
+	Range5V  Range = 0x1 // +/-5V
+	Range2V  Range = 0x3 // +/-2V
+	Range1V  Range = 0x5 // +/-1V
+)
+
+// fullScale maps a Range to its full-scale voltage.
+var fullScale = map[Range]float64{
+	Range10V: 10.0,
+	Range5V:  5.0,
+	Range2V:  2.0,
+	Range1V:  1.0,
+}
+
+// String implements the Stringer interface for Range.
+func (r Range) String() string {
+	switch r {
+	case Range10V:
+		return "+/-10V"
+	case Range5V:
+		return "+/-5V"
+	case Range2V:
+		return "+/-2V"
+	case Range1V:
+		return "+/-1V"
+	default:
+		return "unknown"
+	}
+}
+
+// Channel represents a single analog input channel on the USB-1608FS-Plus.
+type Channel struct {
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Range       Range  `json:"range"`
+}
+
+// Volts converts a little-endian, 16-bit word read from the device's bulk
+// endpoint into the measured voltage for this channel's range.
+func (ch *Channel) Volts(word []byte) (float64, error) {
+	if len(word) != bytesPerWord {
+		return 0, fmt.Errorf("binary value must be %d bytes", bytesPerWord)
+	}
+	rawValue := int(binary.LittleEndian.Uint16(word))
+	return fullScale[ch.Range] * float64(rawValue-converter) / converter, nil
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+// command identifies one of the vendor-specific control transfer requests
+// understood by the USB-1608FS-Plus firmware.
+type command byte
+
+// Commands supported by the USB-1608FS-Plus, per the MCC vendor protocol.
+const (
+	// Digital I/O commands
+	commandDigitalTristate command = 0x00
+	commandDigitalPort     command = 0x01
+	commandDigitalLatch    command = 0x02
+	// Analog input commands
+	commandAnalogInput       command = 0x10
+	commandAnalogStartScan   command = 0x11
+	commandAnalogStopScan    command = 0x12
+	commandAnalogConfig      command = 0x14
+	commandAnalogClearBuffer command = 0x15
+	// Memory commands
+	commandCalibrationMemory command = 0x30
+	// Miscellaneous commands
+	commandBlinkLED        command = 0x41
+	commandReset           command = 0x42
+	commandGetStatus       command = 0x44
+	commandSerialNum       command = 0x48
+	commandUpgradeFirmware command = 0x50
+)
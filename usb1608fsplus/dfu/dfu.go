@@ -0,0 +1,272 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package dfu flashes new firmware onto a USB-1608FS-Plus using the USB
+// DFU 1.1 protocol over libusb control transfers, so users can apply
+// MCC-supplied firmware images without vendor tools.
+package dfu
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gotmc/libusb"
+	"github.com/gotmc/mccdaq/usb1608fsplus"
+)
+
+// bootloaderProductID is the USB product ID the USB-1608FS-Plus
+// re-enumerates with once usb1608fsplus.DAQ.EnterBootloader has erased its
+// program memory. Confirm this against the target descriptor shipped with
+// a given firmware image before relying on it in production.
+const bootloaderProductID = 0x00ec
+
+const (
+	dfuInterfaceNumber = 0
+	blockSize          = 1024
+
+	reenumerateTimeout      = 10 * time.Second
+	reenumeratePollInterval = 200 * time.Millisecond
+	controlTransferTimeout  = 5000
+)
+
+// Standard USB DFU class requests, per DFU 1.1 section 3.
+const (
+	requestDFUDNLoad    = 1
+	requestDFUClrStatus = 4
+	requestDFUGetStatus = 3
+)
+
+// DFU state machine values reported in a DFU_GETSTATUS response's bState
+// field, per DFU 1.1 section 6.1.2.
+const (
+	stateDFUDNLoadIdle        = 5
+	stateDFUManifest          = 7
+	stateDFUManifestWaitReset = 8
+	stateDFUError             = 10
+)
+
+// statusOK is the bStatus value reported in a DFU_GETSTATUS response when
+// the previous request succeeded, per DFU 1.1 section 6.1.2.
+const statusOK = 0
+
+// awaitStateTimeout bounds how long awaitState and awaitManifestation will
+// poll DFU_GETSTATUS before giving up on a device that never reaches the
+// expected state.
+const awaitStateTimeout = 30 * time.Second
+
+// status is the 6-byte response to a DFU_GETSTATUS request.
+type status struct {
+	bStatus       byte
+	bwPollTimeout time.Duration
+	bState        byte
+}
+
+// Flash erases the device's firmware, puts it into DFU bootloader mode,
+// and downloads image to it block by block, reporting progress as it
+// goes. daq is closed as a side effect: the USB-1608FS-Plus disconnects
+// and re-enumerates as a DFU device partway through the flash.
+func Flash(daq *usb1608fsplus.DAQ, image io.Reader, progress func(pct float64)) error {
+	firmware, err := io.ReadAll(image)
+	if err != nil {
+		return fmt.Errorf("error reading firmware image: %s", err)
+	}
+
+	if err := daq.EnterBootloader(); err != nil {
+		return fmt.Errorf("error entering bootloader mode: %s", err)
+	}
+	daq.Close()
+
+	dfuHandle, usbCtx, err := reenumerateAsDFU()
+	if err != nil {
+		return err
+	}
+	defer usbCtx.Exit()
+	defer dfuHandle.Close()
+
+	if err := claimDFUInterface(dfuHandle); err != nil {
+		return err
+	}
+	defer dfuHandle.ReleaseInterface(dfuInterfaceNumber)
+
+	if err := download(dfuHandle, firmware, progress); err != nil {
+		return err
+	}
+	return awaitManifestation(dfuHandle)
+}
+
+// reenumerateAsDFU polls for the device to reappear with the bootloader's
+// VendorID/ProductID and opens it.
+func reenumerateAsDFU() (*libusb.DeviceHandle, *libusb.Context, error) {
+	usbCtx, err := libusb.NewContext()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating USB context: %s", err)
+	}
+	deadline := time.Now().Add(reenumerateTimeout)
+	for {
+		_, deviceHandle, err := usbCtx.OpenDeviceWithVendorProduct(usb1608fsplus.VendorID, bootloaderProductID)
+		if err == nil {
+			return deviceHandle, usbCtx, nil
+		}
+		if time.Now().After(deadline) {
+			usbCtx.Exit()
+			return nil, nil, fmt.Errorf("timed out waiting for DFU device to re-enumerate: %s", err)
+		}
+		time.Sleep(reenumeratePollInterval)
+	}
+}
+
+// claimDFUInterface detaches any kernel driver bound to the DFU interface
+// before claiming it. Detaching isn't supported on macOS, so a failure
+// there is treated as a no-op rather than an error.
+func claimDFUInterface(dh *libusb.DeviceHandle) error {
+	if active, err := dh.KernelDriverActive(dfuInterfaceNumber); err == nil && active {
+		if err := dh.DetachKernelDriver(dfuInterfaceNumber); err != nil {
+			// macOS surfaces an authorization error here since it doesn't allow
+			// detaching kernel drivers; skip and try to claim the interface
+			// anyway.
+			_ = err
+		}
+	}
+	if err := dh.ClaimInterface(dfuInterfaceNumber); err != nil {
+		return fmt.Errorf("error claiming DFU interface: %s", err)
+	}
+	return nil
+}
+
+// download sends firmware to the device in blockSize chunks via
+// DFU_DNLOAD, polling DFU_GETSTATUS after each block until the device
+// reports it's ready for the next one.
+func download(dh *libusb.DeviceHandle, firmware []byte, progress func(pct float64)) error {
+	totalBlocks := (len(firmware) + blockSize - 1) / blockSize
+	for block := 0; block < totalBlocks; block++ {
+		start := block * blockSize
+		end := start + blockSize
+		if end > len(firmware) {
+			end = len(firmware)
+		}
+		if err := dnload(dh, uint16(block), firmware[start:end]); err != nil {
+			return fmt.Errorf("error downloading block %d: %s", block, err)
+		}
+		if err := awaitState(dh, stateDFUDNLoadIdle); err != nil {
+			return fmt.Errorf("error polling status after block %d: %s", block, err)
+		}
+		if progress != nil {
+			progress(100 * float64(block+1) / float64(totalBlocks))
+		}
+	}
+	// A zero-length DFU_DNLOAD signals the end of the transfer.
+	return dnload(dh, uint16(totalBlocks), []byte{})
+}
+
+// dnload issues a single DFU_DNLOAD request for the given block number.
+func dnload(dh *libusb.DeviceHandle, block uint16, data []byte) error {
+	requestType := libusb.BitmapRequestType(
+		libusb.HostToDevice, libusb.Class, libusb.InterfaceRecipient)
+	_, err := dh.ControlTransfer(
+		requestType, requestDFUDNLoad, block, dfuInterfaceNumber, data, len(data),
+		controlTransferTimeout)
+	return err
+}
+
+// getStatus issues a DFU_GETSTATUS request and decodes the response.
+func getStatus(dh *libusb.DeviceHandle) (status, error) {
+	requestType := libusb.BitmapRequestType(
+		libusb.DeviceToHost, libusb.Class, libusb.InterfaceRecipient)
+	data := make([]byte, 6)
+	_, err := dh.ControlTransfer(
+		requestType, requestDFUGetStatus, 0, dfuInterfaceNumber, data, len(data),
+		controlTransferTimeout)
+	if err != nil {
+		return status{}, err
+	}
+	pollTimeoutMS := int(data[1]) | int(data[2])<<8 | int(data[3])<<16
+	return status{
+		bStatus:       data[0],
+		bwPollTimeout: time.Duration(pollTimeoutMS) * time.Millisecond,
+		bState:        data[4],
+	}, nil
+}
+
+// clrStatus issues a DFU_CLRSTATUS request, which clears a device left in
+// dfuERROR and returns it to dfuIDLE, per DFU 1.1 section 6.1.3.
+func clrStatus(dh *libusb.DeviceHandle) error {
+	requestType := libusb.BitmapRequestType(
+		libusb.HostToDevice, libusb.Class, libusb.InterfaceRecipient)
+	_, err := dh.ControlTransfer(
+		requestType, requestDFUClrStatus, 0, dfuInterfaceNumber, []byte{}, 0,
+		controlTransferTimeout)
+	return err
+}
+
+// awaitState repeatedly issues DFU_GETSTATUS, honoring each response's
+// bwPollTimeout, until the device reports it has reached want. It gives up
+// after awaitStateTimeout, and if the device reports dfuERROR it clears the
+// error and returns immediately rather than polling forever for a state
+// the device will never reach.
+func awaitState(dh *libusb.DeviceHandle, want byte) error {
+	deadline := time.Now().Add(awaitStateTimeout)
+	for {
+		st, err := getStatus(dh)
+		if err != nil {
+			return err
+		}
+		if st.bState == stateDFUError {
+			clrErr := clrStatus(dh)
+			if clrErr != nil {
+				return fmt.Errorf("device reported dfuERROR (status %#x) and failed to clear it: %s", st.bStatus, clrErr)
+			}
+			return fmt.Errorf("device reported dfuERROR (status %#x)", st.bStatus)
+		}
+		if st.bStatus != statusOK {
+			return fmt.Errorf("device reported status %#x while awaiting state %d", st.bStatus, want)
+		}
+		if st.bState == want {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for state %d; device still in state %d", want, st.bState)
+		}
+		if st.bwPollTimeout > 0 {
+			time.Sleep(st.bwPollTimeout)
+		}
+	}
+}
+
+// awaitManifestation waits for the device to finish applying the new
+// firmware image and reset. It gives up after awaitStateTimeout and clears
+// the device's error state if manifestation fails instead of flashing.
+func awaitManifestation(dh *libusb.DeviceHandle) error {
+	deadline := time.Now().Add(awaitStateTimeout)
+	for {
+		st, err := getStatus(dh)
+		if err != nil {
+			// The device may have already reset and dropped off the bus, which
+			// is expected once manifestation completes.
+			return nil
+		}
+		if st.bState == stateDFUError {
+			clrErr := clrStatus(dh)
+			if clrErr != nil {
+				return fmt.Errorf("device reported dfuERROR (status %#x) during manifestation and failed to clear it: %s", st.bStatus, clrErr)
+			}
+			return fmt.Errorf("device reported dfuERROR (status %#x) during manifestation", st.bStatus)
+		}
+		if st.bStatus != statusOK {
+			return fmt.Errorf("device reported status %#x during manifestation", st.bStatus)
+		}
+		switch st.bState {
+		case stateDFUManifest, stateDFUManifestWaitReset:
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out waiting for manifestation to complete; device still in state %d", st.bState)
+			}
+			if st.bwPollTimeout > 0 {
+				time.Sleep(st.bwPollTimeout)
+			}
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import "fmt"
+
+// DigitalIO models the USB-1608FS-Plus's single 8-bit digital I/O port,
+// letting callers drive board digital lines directly instead of shelling
+// out to Raspberry Pi GPIO.
+type DigitalIO struct {
+	daq *DAQ
+}
+
+// NewDigitalIO creates a DigitalIO for the given DAQ.
+func (daq *DAQ) NewDigitalIO() (*DigitalIO, error) {
+	return &DigitalIO{daq: daq}, nil
+}
+
+// direction reads the port's current tristate register, where a set bit
+// means the corresponding pin is an input.
+func (dio *DigitalIO) direction() (uint8, error) {
+	data := make([]byte, 1)
+	if _, err := dio.daq.readCommand(commandDigitalTristate, data); err != nil {
+		return 0, fmt.Errorf("error reading digital I/O direction: %s", err)
+	}
+	return data[0], nil
+}
+
+// SetDirection configures the pins selected by mask as inputs where the
+// corresponding bit in dir is 1, or outputs where it's 0. Pins outside mask
+// are left unchanged.
+func (dio *DigitalIO) SetDirection(mask, dir uint8) error {
+	current, err := dio.direction()
+	if err != nil {
+		return err
+	}
+	newDirection := (current &^ mask) | (dir & mask)
+	if _, err := dio.daq.sendCommand(commandDigitalTristate, []byte{newDirection}); err != nil {
+		return fmt.Errorf("error setting digital I/O direction: %s", err)
+	}
+	return nil
+}
+
+// Read returns the current logic level of all eight digital I/O pins.
+func (dio *DigitalIO) Read() (uint8, error) {
+	data := make([]byte, 1)
+	if _, err := dio.daq.readCommand(commandDigitalPort, data); err != nil {
+		return 0, fmt.Errorf("error reading digital I/O port: %s", err)
+	}
+	return data[0], nil
+}
+
+// latch reads the port's current output latch register.
+func (dio *DigitalIO) latch() (uint8, error) {
+	data := make([]byte, 1)
+	if _, err := dio.daq.readCommand(commandDigitalLatch, data); err != nil {
+		return 0, fmt.Errorf("error reading digital I/O output latch: %s", err)
+	}
+	return data[0], nil
+}
+
+// Write sets the output pins selected by mask to the value in val,
+// leaving pins outside mask unchanged. Pins configured as inputs are
+// unaffected until switched to outputs via SetDirection.
+func (dio *DigitalIO) Write(mask, val uint8) error {
+	current, err := dio.latch()
+	if err != nil {
+		return err
+	}
+	newLatch := (current &^ mask) | (val & mask)
+	if _, err := dio.daq.sendCommand(commandDigitalLatch, []byte{newLatch}); err != nil {
+		return fmt.Errorf("error writing digital I/O output latch: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gotmc/mccdaq/usb1608fsplus/sink"
+)
+
+// Recorder couples a continuous AnalogInput scan to a sink.Sink, so an
+// example can shrink to reading its config, choosing a sink, and calling
+// Run. Output split across several files uses RecordSeries instead, as
+// writedata does.
+type Recorder struct {
+	ai             *AnalogInput
+	sink           sink.Sink
+	scansPerBuffer int
+	// maxBuffers stops the scan after that many buffers have been written.
+	// A value of 0 records until ctx is canceled.
+	maxBuffers int
+}
+
+// NewRecorder creates a Recorder that reads scansPerBuffer scans at a time
+// from ai and writes each buffer to s. If maxBuffers is greater than
+// zero, Run stops after writing that many buffers.
+func NewRecorder(ai *AnalogInput, s sink.Sink, scansPerBuffer, maxBuffers int) *Recorder {
+	return &Recorder{
+		ai:             ai,
+		sink:           s,
+		scansPerBuffer: scansPerBuffer,
+		maxBuffers:     maxBuffers,
+	}
+}
+
+// headerMeta builds the HeaderMeta written to a sink before its first
+// buffer, including the per-channel range/description needed to convert a
+// recorded raw word back to volts and the file-series bookkeeping fields
+// (fileNum/numFiles/buffersPerFile) a multi-file recording splits across.
+// rtcTime, if non-nil, is called to fill in HeaderMeta.RTCTime from an
+// external real-time clock; it's left zero otherwise.
+func headerMeta(ai *AnalogInput, scansPerBuffer, buffersPerFile, numFiles, fileNum int, rtcTime func() string) sink.HeaderMeta {
+	channels := make([]sink.Channel, len(ai.Channels))
+	for i, ch := range ai.Channels {
+		channels[i] = sink.Channel{
+			Description: ch.Description,
+			Enabled:     ch.Enabled,
+			Range:       byte(ch.Range),
+		}
+	}
+	meta := sink.HeaderMeta{
+		ScansPerBuffer:     scansPerBuffer,
+		BuffersPerFile:     buffersPerFile,
+		NumFiles:           numFiles,
+		FileNum:            fileNum,
+		NumEnabledChannels: ai.NumEnabledChannels(),
+		Channels:           channels,
+		Frequency:          ai.Frequency,
+		SystemTime:         time.Now(),
+	}
+	if rtcTime != nil {
+		meta.RTCTime = rtcTime()
+	}
+	return meta
+}
+
+// Run starts the scan, streams buffers into the Recorder's sink until
+// maxBuffers is reached or ctx is canceled, and stops the scan before
+// returning.
+func (r *Recorder) Run(ctx context.Context) error {
+	meta := headerMeta(r.ai, r.scansPerBuffer, r.maxBuffers, 1, 0, nil)
+	if err := r.sink.WriteHeader(meta); err != nil {
+		return fmt.Errorf("error writing recorder header: %s", err)
+	}
+	defer r.sink.Close()
+
+	if err := r.ai.StartScan(0); err != nil {
+		return fmt.Errorf("error starting recorder scan: %s", err)
+	}
+	defer r.ai.StopScan()
+
+	var writeErr error
+	buffersWritten := 0
+	err := r.ai.StreamAsync(r.scansPerBuffer, func(samples []int16, m ScanMeta) StreamStatus {
+		select {
+		case <-ctx.Done():
+			return StreamShutdown
+		default:
+		}
+		if err := r.sink.WriteBuffer(m.BufferIndex, samples); err != nil {
+			writeErr = fmt.Errorf("error writing recorder buffer: %s", err)
+			return StreamShutdown
+		}
+		buffersWritten++
+		if r.maxBuffers > 0 && buffersWritten >= r.maxBuffers {
+			return StreamShutdown
+		}
+		return StreamContinue
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+// RecordSeries streams one continuous analog input scan across numFiles
+// sinks, each created in turn by newSink once the previous one has
+// received buffersPerFile buffers. Splitting output this way, rather than
+// calling Recorder.Run once per file, keeps the scan running start to
+// finish: the scan is started once before the first sink and stopped once
+// after the last, so there's no start/clear/restart gap at file
+// boundaries. rtcTime, if non-nil, is called once per file to fill in
+// HeaderMeta.RTCTime from an external real-time clock. A buffersPerFile of
+// 0 is treated as "never switch files" and records everything to the first
+// sink.
+func RecordSeries(ctx context.Context, ai *AnalogInput, scansPerBuffer, buffersPerFile, numFiles int, newSink func(fileNum int) (sink.Sink, error), rtcTime func() string) error {
+	if numFiles <= 0 {
+		return nil
+	}
+
+	fileNum := 0
+	cur, err := newSeriesSink(ai, scansPerBuffer, buffersPerFile, numFiles, fileNum, newSink, rtcTime)
+	if err != nil {
+		return err
+	}
+
+	if err := ai.StartScan(0); err != nil {
+		cur.Close()
+		return fmt.Errorf("error starting recorder scan: %s", err)
+	}
+	defer ai.StopScan()
+
+	var writeErr error
+	buffersWritten := 0
+	streamErr := ai.StreamAsync(scansPerBuffer, func(samples []int16, m ScanMeta) StreamStatus {
+		select {
+		case <-ctx.Done():
+			return StreamShutdown
+		default:
+		}
+		if err := cur.WriteBuffer(buffersWritten, samples); err != nil {
+			writeErr = fmt.Errorf("error writing recorder buffer: %s", err)
+			return StreamShutdown
+		}
+		buffersWritten++
+		if buffersPerFile <= 0 || buffersWritten < buffersPerFile {
+			return StreamContinue
+		}
+		if err := cur.Close(); err != nil {
+			writeErr = fmt.Errorf("error closing sink for file %d: %s", fileNum, err)
+			return StreamShutdown
+		}
+		cur = nil
+		fileNum++
+		if fileNum >= numFiles {
+			return StreamShutdown
+		}
+		next, err := newSeriesSink(ai, scansPerBuffer, buffersPerFile, numFiles, fileNum, newSink, rtcTime)
+		if err != nil {
+			writeErr = err
+			return StreamShutdown
+		}
+		cur = next
+		buffersWritten = 0
+		return StreamContinue
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	if streamErr != nil {
+		return streamErr
+	}
+	// cur is already closed if the series ended exactly on a file boundary
+	// (the common case, since writedata's buffers_per_file/num_files are
+	// meant to divide the scan evenly); only close it here if it's still
+	// open, e.g. because ctx was canceled mid-file.
+	if cur == nil {
+		return nil
+	}
+	return cur.Close()
+}
+
+// newSeriesSink creates the sink for fileNum and writes its header.
+func newSeriesSink(ai *AnalogInput, scansPerBuffer, buffersPerFile, numFiles, fileNum int, newSink func(fileNum int) (sink.Sink, error), rtcTime func() string) (sink.Sink, error) {
+	s, err := newSink(fileNum)
+	if err != nil {
+		return nil, fmt.Errorf("error creating sink for file %d: %s", fileNum, err)
+	}
+	meta := headerMeta(ai, scansPerBuffer, buffersPerFile, numFiles, fileNum, rtcTime)
+	if err := s.WriteHeader(meta); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("error writing header for file %d: %s", fileNum, err)
+	}
+	return s, nil
+}
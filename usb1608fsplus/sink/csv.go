@@ -0,0 +1,70 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package sink
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// CSVSink writes scan buffers as comma-separated rows, one row per scan and
+// one column per enabled channel, for spreadsheet workflows.
+type CSVSink struct {
+	file        *os.File
+	writer      *csv.Writer
+	numChannels int
+}
+
+// NewCSVSink creates a CSVSink writing to path.
+func NewCSVSink(path string) (*CSVSink, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CSV file: %s", err)
+	}
+	return &CSVSink{file: file, writer: csv.NewWriter(file)}, nil
+}
+
+// WriteHeader writes the column header row, one column per enabled
+// channel.
+func (s *CSVSink) WriteHeader(meta HeaderMeta) error {
+	s.numChannels = meta.NumEnabledChannels
+	row := make([]string, s.numChannels)
+	for i := range row {
+		row[i] = fmt.Sprintf("channel_%d", i)
+	}
+	if err := s.writer.Write(row); err != nil {
+		return fmt.Errorf("error writing CSV header row: %s", err)
+	}
+	return nil
+}
+
+// WriteBuffer writes one CSV row per scan in the buffer.
+func (s *CSVSink) WriteBuffer(scanIndex int, samples []int16) error {
+	if s.numChannels == 0 {
+		return fmt.Errorf("CSVSink.WriteHeader must be called before WriteBuffer")
+	}
+	for scanStart := 0; scanStart+s.numChannels <= len(samples); scanStart += s.numChannels {
+		row := make([]string, s.numChannels)
+		for i := 0; i < s.numChannels; i++ {
+			row[i] = strconv.Itoa(int(samples[scanStart+i]))
+		}
+		if err := s.writer.Write(row); err != nil {
+			return fmt.Errorf("error writing CSV row: %s", err)
+		}
+	}
+	return nil
+}
+
+// Close flushes buffered rows and closes the file.
+func (s *CSVSink) Close() error {
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %s", err)
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package sink
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FileSink reproduces the split header (.hdr)/binary (.dat) files that the
+// writedata example used to write directly: a human-readable JSON header
+// alongside a flat, little-endian binary file of the raw sample words.
+type FileSink struct {
+	headerPath string
+	dataFile   *os.File
+}
+
+// NewFileSink creates a FileSink that writes basePath+".hdr" and
+// basePath+".dat" once WriteHeader and WriteBuffer are called.
+func NewFileSink(basePath string) (*FileSink, error) {
+	dataFile, err := os.Create(basePath + ".dat")
+	if err != nil {
+		return nil, fmt.Errorf("error creating data file: %s", err)
+	}
+	return &FileSink{
+		headerPath: basePath + ".hdr",
+		dataFile:   dataFile,
+	}, nil
+}
+
+// WriteHeader writes meta as indented JSON to the header file.
+func (s *FileSink) WriteHeader(meta HeaderMeta) error {
+	data, err := json.MarshalIndent(&meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling header: %s", err)
+	}
+	if err := os.WriteFile(s.headerPath, data, 0666); err != nil {
+		return fmt.Errorf("error writing header file: %s", err)
+	}
+	return nil
+}
+
+// WriteBuffer appends samples to the data file as little-endian words.
+func (s *FileSink) WriteBuffer(scanIndex int, samples []int16) error {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	if _, err := s.dataFile.Write(data); err != nil {
+		return fmt.Errorf("error writing data file: %s", err)
+	}
+	return nil
+}
+
+// Close closes the data file.
+func (s *FileSink) Close() error {
+	return s.dataFile.Close()
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package sink provides destinations for USB-1608FS-Plus scan data,
+// extracted from the file-writing logic that used to be hard-coded into
+// the writedata example.
+package sink
+
+import "time"
+
+// Channel describes one analog input channel's configuration. It's carried
+// in HeaderMeta, rather than the Sink package depending on usb1608fsplus's
+// Channel type, so a header still has what's needed to convert a recorded
+// raw word back to volts.
+type Channel struct {
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Range       byte   `json:"range"`
+}
+
+// HeaderMeta describes the scan that produced the buffers passed to a
+// Sink, written once via WriteHeader before any buffers arrive.
+type HeaderMeta struct {
+	ScansPerBuffer     int       `json:"scans_per_buffer"`
+	BuffersPerFile     int       `json:"buffers_per_file"`
+	NumFiles           int       `json:"num_files"`
+	FileNum            int       `json:"file_num"`
+	NumEnabledChannels int       `json:"num_enabled_channels"`
+	Channels           []Channel `json:"channels"`
+	Frequency          float64   `json:"frequency"`
+	SystemTime         time.Time `json:"system_time"`
+	RTCTime            string    `json:"rtc_time,omitempty"`
+}
+
+// Sink is a destination for the buffers produced by a continuous analog
+// input scan.
+type Sink interface {
+	// WriteHeader records the scan configuration. It's called once, before
+	// the first call to WriteBuffer.
+	WriteHeader(meta HeaderMeta) error
+	// WriteBuffer records one buffer's worth of samples, interleaved by
+	// enabled channel, as delivered by AnalogInput.StreamAsync.
+	WriteBuffer(scanIndex int, samples []int16) error
+	// Close flushes and releases any resources held by the Sink.
+	Close() error
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package sink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+const (
+	wavHeaderSize    = 44
+	wavBitsPerSample = 16
+)
+
+// WAVSink writes scan buffers as a multichannel, 16-bit PCM WAV file so
+// they can be loaded into standard signal-analysis tools.
+type WAVSink struct {
+	file        *os.File
+	numChannels int
+	sampleRate  int
+	dataBytes   int
+}
+
+// NewWAVSink creates a WAVSink writing to path. The WAV header is
+// finalized when Close is called, once the total data size is known.
+func NewWAVSink(path string) (*WAVSink, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating WAV file: %s", err)
+	}
+	// Reserve space for the header; it's rewritten by Close.
+	if _, err := file.Write(make([]byte, wavHeaderSize)); err != nil {
+		return nil, fmt.Errorf("error reserving WAV header: %s", err)
+	}
+	return &WAVSink{file: file}, nil
+}
+
+// WriteHeader records the channel count and sample rate needed to
+// finalize the WAV header at Close.
+func (s *WAVSink) WriteHeader(meta HeaderMeta) error {
+	s.numChannels = meta.NumEnabledChannels
+	s.sampleRate = int(meta.Frequency)
+	return nil
+}
+
+// WriteBuffer appends samples, interleaved by channel, to the WAV data
+// chunk.
+func (s *WAVSink) WriteBuffer(scanIndex int, samples []int16) error {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("error writing WAV data: %s", err)
+	}
+	s.dataBytes += len(data)
+	return nil
+}
+
+// Close writes the finalized WAV header and closes the file.
+func (s *WAVSink) Close() error {
+	defer s.file.Close()
+	numChannels := s.numChannels
+	if numChannels == 0 {
+		numChannels = 1
+	}
+	blockAlign := numChannels * (wavBitsPerSample / 8)
+	byteRate := s.sampleRate * blockAlign
+
+	header := make([]byte, wavHeaderSize)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+s.dataBytes))
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16) // fmt subchunk size
+	binary.LittleEndian.PutUint16(header[20:22], 1)  // PCM
+	binary.LittleEndian.PutUint16(header[22:24], uint16(numChannels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(s.sampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(byteRate))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], wavBitsPerSample)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(s.dataBytes))
+
+	if _, err := s.file.WriteAt(header, 0); err != nil {
+		return fmt.Errorf("error finalizing WAV header: %s", err)
+	}
+	return nil
+}
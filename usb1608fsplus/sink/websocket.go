@@ -0,0 +1,81 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package sink
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// bufferSummary is what WebSocketSink sends for each buffer: enough for a
+// browser dashboard to plot a running trace without shipping every raw
+// sample over the wire.
+type bufferSummary struct {
+	ScanIndex int     `json:"scan_index"`
+	NumScans  int     `json:"num_scans"`
+	Min       int16   `json:"min"`
+	Max       int16   `json:"max"`
+	Mean      float64 `json:"mean"`
+}
+
+// WebSocketSink JSON-encodes a summary of each buffer and streams it over
+// an existing WebSocket connection, replacing the termui-only
+// visualization with something a browser dashboard can consume.
+type WebSocketSink struct {
+	conn        *websocket.Conn
+	numChannels int
+}
+
+// NewWebSocketSink creates a WebSocketSink that writes to conn. The caller
+// owns conn and is responsible for the initial HTTP upgrade.
+func NewWebSocketSink(conn *websocket.Conn) *WebSocketSink {
+	return &WebSocketSink{conn: conn}
+}
+
+// WriteHeader sends meta to the browser as the first JSON message.
+func (s *WebSocketSink) WriteHeader(meta HeaderMeta) error {
+	s.numChannels = meta.NumEnabledChannels
+	if err := s.conn.WriteJSON(meta); err != nil {
+		return fmt.Errorf("error writing WebSocket header: %s", err)
+	}
+	return nil
+}
+
+// WriteBuffer sends a bufferSummary for samples as a JSON message.
+func (s *WebSocketSink) WriteBuffer(scanIndex int, samples []int16) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	summary := bufferSummary{
+		ScanIndex: scanIndex,
+		Min:       samples[0],
+		Max:       samples[0],
+	}
+	if s.numChannels > 0 {
+		summary.NumScans = len(samples) / s.numChannels
+	}
+	var sum int64
+	for _, sample := range samples {
+		if sample < summary.Min {
+			summary.Min = sample
+		}
+		if sample > summary.Max {
+			summary.Max = sample
+		}
+		sum += int64(sample)
+	}
+	summary.Mean = float64(sum) / float64(len(samples))
+	if err := s.conn.WriteJSON(summary); err != nil {
+		return fmt.Errorf("error writing WebSocket buffer summary: %s", err)
+	}
+	return nil
+}
+
+// Close closes the underlying WebSocket connection.
+func (s *WebSocketSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+)
+
+// StreamStatus is returned by a StreamCallback to tell StreamAsync how to
+// proceed with the scan.
+type StreamStatus int
+
+const (
+	// StreamContinue tells StreamAsync to keep reading scans.
+	StreamContinue StreamStatus = iota
+	// StreamSkip tells StreamAsync to drop the buffer just delivered and keep
+	// reading. The drop is counted and reported in the next ScanMeta.
+	StreamSkip
+	// StreamShutdown tells StreamAsync to stop the scan and return.
+	StreamShutdown
+)
+
+// inFlightBuffers is the number of decoded buffers StreamAsync's reader
+// goroutine may hold ready ahead of the callback. The reader issues one
+// bulk transfer at a time; it does not keep several transfers outstanding.
+const inFlightBuffers = 3
+
+// ScanMeta describes the buffer handed to a StreamCallback.
+type ScanMeta struct {
+	// BufferIndex is incremented for every buffer delivered to the callback,
+	// starting at zero.
+	BufferIndex int
+	// ScansRead is the number of scans (one sample per enabled channel)
+	// contained in the delivered buffer.
+	ScansRead int
+	// DroppedBuffers is the running count of buffers dropped because the
+	// callback returned StreamSkip.
+	DroppedBuffers uint64
+	// Underruns is the running count of buffers lost because the device
+	// produced data faster than StreamAsync could read it.
+	Underruns uint64
+}
+
+// StreamCallback receives the decoded samples for one buffer, interleaved
+// by enabled channel, along with metadata about the buffer and the scan.
+type StreamCallback func(samples []int16, meta ScanMeta) StreamStatus
+
+// StreamAsync continuously reads scansPerBuffer scans at a time and passes
+// the decoded samples to callback. A reader goroutine calls ReadScan in a
+// loop, one transfer at a time, and queues up to inFlightBuffers decoded
+// buffers, so the next ReadScan is already underway while callback is
+// running, avoiding the stalls seen when examples call ReadScan directly in
+// their main loop. StreamAsync returns
+// when callback returns StreamShutdown, when ReadScan returns an error, or
+// when the scan otherwise stops.
+func (ai *AnalogInput) StreamAsync(scansPerBuffer int, callback StreamCallback) error {
+	numEnabled := ai.NumEnabledChannels()
+	if numEnabled == 0 {
+		return fmt.Errorf("error streaming analog input: no channels enabled")
+	}
+
+	atomic.StoreUint64(&ai.droppedBuffers, 0)
+	atomic.StoreUint64(&ai.underruns, 0)
+
+	type result struct {
+		samples []int16
+		err     error
+	}
+
+	results := make(chan result, inFlightBuffers)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(results)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			raw, err := ai.ReadScan(scansPerBuffer)
+			if err != nil {
+				select {
+				case results <- result{err: err}:
+				case <-done:
+				}
+				return
+			}
+			if st, err := ai.status(); err == nil && st&statusScanOverrun != 0 {
+				atomic.AddUint64(&ai.underruns, 1)
+			}
+			select {
+			case results <- result{samples: decodeInt16LE(raw)}:
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer close(done)
+
+	bufferIndex := 0
+	for res := range results {
+		if res.err != nil {
+			return fmt.Errorf("error streaming analog input: %s", res.err)
+		}
+		meta := ScanMeta{
+			BufferIndex:    bufferIndex,
+			ScansRead:      len(res.samples) / numEnabled,
+			DroppedBuffers: atomic.LoadUint64(&ai.droppedBuffers),
+			Underruns:      atomic.LoadUint64(&ai.underruns),
+		}
+		switch callback(res.samples, meta) {
+		case StreamShutdown:
+			return ai.StopScan()
+		case StreamSkip:
+			atomic.AddUint64(&ai.droppedBuffers, 1)
+		}
+		bufferIndex++
+	}
+	return nil
+}
+
+// DroppedBuffers returns the number of buffers dropped by the most recent
+// StreamAsync call because the callback returned StreamSkip.
+func (ai *AnalogInput) DroppedBuffers() uint64 {
+	return atomic.LoadUint64(&ai.droppedBuffers)
+}
+
+// Underruns returns the number of buffers lost during the most recent
+// StreamAsync call because the device produced data faster than it could
+// be read.
+func (ai *AnalogInput) Underruns() uint64 {
+	return atomic.LoadUint64(&ai.underruns)
+}
+
+// decodeInt16LE decodes a buffer of little-endian 16-bit words, as read
+// from the device's bulk endpoint, into signed samples.
+func decodeInt16LE(raw []byte) []int16 {
+	samples := make([]int16, len(raw)/bytesPerWord)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(raw[i*bytesPerWord:]))
+	}
+	return samples
+}
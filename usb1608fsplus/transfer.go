@@ -0,0 +1,82 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// transfer builds up the data for a single command/response control
+// transfer, then submits it via daq's control endpoint. It replaces the
+// bespoke buffer-index bookkeeping (binary.LittleEndian.PutUint32 into a
+// hand-sized byte slice at a hand-tracked offset) that used to be repeated
+// in every scan-setup function.
+type transfer struct {
+	daq  *DAQ
+	cmd  command
+	body bytes.Buffer
+}
+
+// newTransfer starts building a command/response transaction for cmd.
+func newTransfer(daq *DAQ, cmd command) *transfer {
+	return &transfer{daq: daq, cmd: cmd}
+}
+
+// WriteByte appends a single byte to the command body. It never fails; the
+// error return exists only to satisfy io.ByteWriter.
+func (t *transfer) WriteByte(b byte) error {
+	return t.body.WriteByte(b)
+}
+
+// WriteUint16LE appends v to the command body as a little-endian, 2-byte
+// word.
+func (t *transfer) WriteUint16LE(v uint16) {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	t.body.Write(b[:])
+}
+
+// WriteUint32LE appends v to the command body as a little-endian, 4-byte
+// word.
+func (t *transfer) WriteUint32LE(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	t.body.Write(b[:])
+}
+
+// Execute submits the accumulated command body over the control endpoint.
+// If expectedRespLen is 0, the body is written to the device and Execute
+// returns no data. Otherwise Execute reads back expectedRespLen bytes and
+// validates that the device returned exactly that many; since a read
+// transfer carries no outgoing data, it is an error to have written
+// anything to the body first.
+func (t *transfer) Execute(expectedRespLen int) ([]byte, error) {
+	defer t.body.Reset()
+	if expectedRespLen == 0 {
+		if _, err := t.daq.sendCommand(t.cmd, t.body.Bytes()); err != nil {
+			return nil, fmt.Errorf("error executing command %#x: %s", byte(t.cmd), err)
+		}
+		return nil, nil
+	}
+	if t.body.Len() != 0 {
+		return nil, fmt.Errorf(
+			"command %#x wrote %d bytes to its body but also requested a %d-byte response; "+
+				"Execute only supports writing a command or reading a response, not both",
+			byte(t.cmd), t.body.Len(), expectedRespLen)
+	}
+	resp := make([]byte, expectedRespLen)
+	bytesRead, err := t.daq.readCommand(t.cmd, resp)
+	if err != nil {
+		return nil, fmt.Errorf("error executing command %#x: %s", byte(t.cmd), err)
+	}
+	if bytesRead != expectedRespLen {
+		return nil, fmt.Errorf(
+			"command %#x returned %d bytes, expected %d", byte(t.cmd), bytesRead, expectedRespLen)
+	}
+	return resp, nil
+}
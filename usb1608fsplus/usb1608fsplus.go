@@ -0,0 +1,138 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+// Package usb1608fsplus provides a driver for the Measurement Computing
+// USB-1608FS-Plus data acquisition device.
+//
+// The USB-1608FS-Plus has no DAC or analog output FIFO, unlike some
+// adjacent MCC USB DAQ boards. AnalogOutput exists only so that an
+// analog_config.json written for a board that does have output channels
+// decodes without error; every one of its methods returns an error. This
+// is a hardware limitation, not an oversight — see AnalogOutput's doc
+// comment for the detail.
+package usb1608fsplus
+
+import (
+	"fmt"
+
+	"github.com/gotmc/libusb"
+)
+
+// VendorID is the USB vendor ID the USB-1608FS-Plus enumerates with while
+// running its normal firmware. It's exported so that dfu, which talks to
+// the device's bootloader under a different product ID but the same
+// vendor ID, doesn't have to hard-code a second copy.
+const VendorID = 0x09db
+
+const (
+	productID      = 0x00ea
+	defaultTimeout = 2000
+)
+
+// DAQ models the MCC USB-1608FS-Plus data acquisition device.
+type DAQ struct {
+	Timeout          int
+	Device           *libusb.Device
+	DeviceDescriptor *libusb.DeviceDescriptor
+	DeviceHandle     *libusb.DeviceHandle
+}
+
+// Init initializes a new libusb session/context by creating a new Context
+// and returning a pointer to that Context.
+func Init() (*libusb.Context, error) {
+	return libusb.NewContext()
+}
+
+// GetFirstDevice creates a new DAQ using the first USB-1608FS-Plus found in
+// the given USB context.
+func GetFirstDevice(ctx *libusb.Context) (*DAQ, error) {
+	device, deviceHandle, err := ctx.OpenDeviceWithVendorProduct(VendorID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the DAQ: %s", err)
+	}
+	return create(device, deviceHandle)
+}
+
+func create(device *libusb.Device, deviceHandle *libusb.DeviceHandle) (*DAQ, error) {
+	if err := deviceHandle.ClaimInterface(0); err != nil {
+		return nil, fmt.Errorf("error claiming the bulk interface: %s", err)
+	}
+	deviceDescriptor, err := device.GetDeviceDescriptor()
+	if err != nil {
+		return nil, fmt.Errorf("error getting device descriptor: %s", err)
+	}
+	return &DAQ{
+		Timeout:          defaultTimeout,
+		Device:           device,
+		DeviceDescriptor: deviceDescriptor,
+		DeviceHandle:     deviceHandle,
+	}, nil
+}
+
+// Close releases the USB interface and closes the device handle for the
+// DAQ.
+func (daq *DAQ) Close() error {
+	if err := daq.DeviceHandle.ReleaseInterface(0); err != nil {
+		return fmt.Errorf("error releasing interface: %s", err)
+	}
+	daq.DeviceHandle.Close()
+	return nil
+}
+
+// sendCommand sends the given command and data to the device via a vendor
+// control transfer.
+func (daq *DAQ) sendCommand(cmd command, data []byte) (int, error) {
+	if data == nil {
+		data = []byte{0}
+	}
+	requestType := libusb.BitmapRequestType(
+		libusb.HostToDevice, libusb.Vendor, libusb.DeviceRecipient)
+	bytesSent, err := daq.DeviceHandle.ControlTransfer(
+		requestType, byte(cmd), 0x0, 0x0, data, len(data), daq.Timeout)
+	if err != nil {
+		return bytesSent, fmt.Errorf("error sending command %#x to device: %s", byte(cmd), err)
+	}
+	return bytesSent, nil
+}
+
+// readCommand sends a command to the DAQ and reads back its response via a
+// vendor control transfer.
+func (daq *DAQ) readCommand(cmd command, data []byte) (int, error) {
+	requestType := libusb.BitmapRequestType(
+		libusb.DeviceToHost, libusb.Vendor, libusb.DeviceRecipient)
+	bytesRead, err := daq.DeviceHandle.ControlTransfer(
+		requestType, byte(cmd), 0x0, 0x0, data, len(data), daq.Timeout)
+	if err != nil {
+		return bytesRead, fmt.Errorf("error reading command %#x from device: %s", byte(cmd), err)
+	}
+	return bytesRead, nil
+}
+
+// EnterBootloader erases a portion of the device's program memory and
+// tells it to enumerate in DFU bootloader mode on its next USB reset. Once
+// erased, the device is unusable as a DAQ until new firmware is loaded via
+// the dfu subpackage; use it with care.
+func (daq *DAQ) EnterBootloader() error {
+	requestType := libusb.BitmapRequestType(
+		libusb.HostToDevice, libusb.Vendor, libusb.DeviceRecipient)
+	const unlockKey = 0xadad
+	_, err := daq.DeviceHandle.ControlTransfer(
+		requestType, byte(commandUpgradeFirmware), unlockKey, 0x0, []byte{}, 0, daq.Timeout)
+	if err != nil {
+		return fmt.Errorf("error entering bootloader mode: %s", err)
+	}
+	return nil
+}
+
+// SerialNumber retrieves the serial number via a control transfer using the
+// device's serial number command, as opposed to reading the libusb string
+// descriptor.
+func (daq *DAQ) SerialNumber() (string, error) {
+	data, err := newTransfer(daq, commandSerialNum).Execute(8)
+	if err != nil {
+		return "", fmt.Errorf("error reading serial number: %s", err)
+	}
+	return string(data), nil
+}
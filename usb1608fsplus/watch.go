@@ -0,0 +1,145 @@
+// Copyright (c) 2016 The mccdaq developers. All rights reserved.
+// Project site: https://github.com/gotmc/mccdaq
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package usb1608fsplus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gotmc/libusb"
+)
+
+// defaultPollInterval is how often Watch rescans the USB device list when
+// no interval is provided.
+const defaultPollInterval = 1 * time.Second
+
+// DeviceEvent is implemented by DeviceArrived and DeviceLeft, the events
+// sent on the channel returned by Watch.
+type DeviceEvent interface {
+	deviceEvent()
+}
+
+// DeviceArrived is sent on the Watch channel when a USB-1608FS-Plus is
+// plugged in and successfully opened.
+type DeviceArrived struct {
+	Device *DAQ
+}
+
+func (DeviceArrived) deviceEvent() {}
+
+// DeviceLeft is sent on the Watch channel when a previously arrived
+// USB-1608FS-Plus is unplugged.
+type DeviceLeft struct {
+	Serial string
+}
+
+func (DeviceLeft) deviceEvent() {}
+
+// busAddress identifies a device's position on the USB bus so a device can
+// be recognized across polls even before it has been opened.
+type busAddress struct {
+	bus     int
+	address int
+}
+
+// Watch polls the USB device list every pollInterval looking for
+// USB-1608FS-Plus devices that have arrived or left, sending a DeviceEvent
+// for each change on the returned channel. The channel is closed and
+// polling stops when ctx is canceled.
+//
+// github.com/gotmc/libusb does not expose libusb's hotplug callback API, so
+// Watch is implemented by polling GetDeviceList and diffing by bus/address
+// rather than registering for hotplug notifications directly.
+func Watch(ctx context.Context, pollInterval time.Duration) (<-chan DeviceEvent, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	usbCtx, err := Init()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing USB context for Watch: %s", err)
+	}
+	events := make(chan DeviceEvent)
+	go watchLoop(ctx, usbCtx, pollInterval, events)
+	return events, nil
+}
+
+func watchLoop(
+	ctx context.Context, usbCtx *libusb.Context, pollInterval time.Duration, events chan<- DeviceEvent,
+) {
+	defer close(events)
+	defer usbCtx.Exit()
+
+	known := make(map[busAddress]string) // busAddress -> serial number
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	poll := func() {
+		present := make(map[busAddress]bool)
+		devices, err := usbCtx.GetDeviceList()
+		if err != nil {
+			return
+		}
+		for _, device := range devices {
+			descriptor, err := device.GetDeviceDescriptor()
+			if err != nil || descriptor.VendorID != VendorID || descriptor.ProductID != productID {
+				continue
+			}
+			bus, err := device.GetBusNumber()
+			if err != nil {
+				continue
+			}
+			address, err := device.GetDeviceAddress()
+			if err != nil {
+				continue
+			}
+			addr := busAddress{bus: bus, address: address}
+			present[addr] = true
+			if _, alreadyKnown := known[addr]; alreadyKnown {
+				continue
+			}
+			deviceHandle, err := device.Open()
+			if err != nil {
+				continue
+			}
+			daq, err := create(device, deviceHandle)
+			if err != nil {
+				continue
+			}
+			serial, err := daq.SerialNumber()
+			if err != nil {
+				serial = ""
+			}
+			known[addr] = serial
+			select {
+			case events <- DeviceArrived{Device: daq}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for addr, serial := range known {
+			if !present[addr] {
+				delete(known, addr)
+				select {
+				case events <- DeviceLeft{Serial: serial}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+
+	poll()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
@@ -7,6 +7,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -15,17 +16,23 @@ import (
 	"os"
 	"os/exec"
 	"path"
-	"time"
 
 	"github.com/gotmc/mccdaq/usb1608fsplus"
+	"github.com/gotmc/mccdaq/usb1608fsplus/sink"
 	"github.com/mitchellh/go-homedir"
 	rpi "github.com/nathan-osman/go-rpigpio"
 )
 
-const (
-	millisecondDelay = 100
-	termWidth        = 70
-)
+// rtcTime reads the Raspberry Pi's hardware clock for the header's RTCTime
+// field. It returns "" if hwclock isn't available, which happens when
+// running off the Pi (e.g. in tests).
+func rtcTime() string {
+	out, err := exec.Command("hwclock", "-r").Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
 
 func main() {
 
@@ -104,7 +111,6 @@ func main() {
 		log.Fatalf("Error creating new analog input: %s", err)
 	}
 	ai.StopScan()
-	time.Sleep(millisecondDelay * time.Millisecond)
 	ai.ClearScanBuffer()
 
 	/**************************
@@ -136,91 +142,28 @@ func main() {
 	numFiles := configJSON.NumFiles
 	ai.SetScanRanges()
 
-	var headerJSON = struct {
-		OutputFile                string    `json:"output_file"`
-		ScansPerBuffer            int       `json:"scans_per_buffer"`
-		BuffersPerFile            int       `json:"buffers_per_file"`
-		NumFiles                  int       `json:"num_files"`
-		FileNum                   int       `json:"file_num"`
-		SystemTime                time.Time `json:"system_time"`
-		RTCTime                   string    `json:"rtc_time"`
-		usb1608fsplus.AnalogInput `json:"analog_input"`
-	}{
-		"",
-		scansPerBuffer,
-		buffersPerFile,
-		numFiles,
-		0,
-		time.Now(),
-		"",
-		*ai,
-	}
-
 	// Setup dir to hold output files.
-	err = os.MkdirAll(outputDir, 0755)
-	if err != nil {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		log.Fatalf("Could not create output dir: %s", err)
 	}
 	baseFilename := path.Base(outputDir)
-	headerJSON.OutputFile = baseFilename
 
 	// Read the scan ranges
-	time.Sleep(millisecondDelay * time.Millisecond)
-	_, err = ai.ScanRanges()
-
-	// Read the totalScans using splitScansIn number of scans
-	ai.StartScan(0)
-	totalBytesRead := 0
-
-	bytesPerWord := 2
-	expectedBytesPerFile := ai.NumEnabledChannels() * bytesPerWord * scansPerBuffer * buffersPerFile
-
-	c := make(chan string)
-
-	for fileNum := 0; fileNum < numFiles; fileNum++ {
-		dataForFile := make([]byte, 0, expectedBytesPerFile)
-		headerJSON.FileNum = fileNum
-		go getRTCTime(c)
-		headerJSON.SystemTime = time.Now()
-		for bufferNum := 0; bufferNum < buffersPerFile; bufferNum++ {
-			data, err := ai.ReadScan(scansPerBuffer)
-			totalBytesRead += len(data)
-			if err != nil {
-				// Stop the analog scan and close the DAQ
-				ai.StopScan()
-				time.Sleep(millisecondDelay * time.Millisecond)
-				daq.Close()
-				log.Fatalf("Error reading scan: %s", err)
-			}
-			// Data is good so append
-			dataForFile = append(dataForFile, data...)
-		}
-		headerJSON.RTCTime = <-c
-		// Write the data to the output
-		headerData, err := json.MarshalIndent(&headerJSON, "", "  ")
-		if err != nil {
-			headerData = []byte("Bad header")
-		}
-		headerFilename := fmt.Sprintf("%s_%d.hdr", baseFilename, fileNum)
-		headerPath := path.Join(outputDir, headerFilename)
-		go ioutil.WriteFile(headerPath, headerData, 0666)
-		binaryFilename := fmt.Sprintf("%s_%d.dat", baseFilename, fileNum)
-		binaryPath := path.Join(outputDir, binaryFilename)
-		log.Printf("Writing %s", binaryFilename)
-		go ioutil.WriteFile(binaryPath, dataForFile, 0666)
+	if _, err := ai.ScanRanges(); err != nil {
+		log.Fatalf("Error reading scan ranges: %s", err)
 	}
 
-	// Stop the analog scan and close the DAQ
-	time.Sleep(millisecondDelay * time.Millisecond)
-	ai.StopScan()
-	time.Sleep(millisecondDelay * time.Millisecond)
-}
-
-func getRTCTime(c chan string) {
-	var cmdOut []byte
-	var err error
-	if cmdOut, err = exec.Command("hwclock", "-r").Output(); err != nil {
-		c <- "bad hwclock call"
+	// RecordSeries keeps one scan running for the whole series, handing
+	// successive buffer ranges to a new FileSink at each file boundary,
+	// instead of starting and stopping the scan once per file.
+	newSink := func(fileNum int) (sink.Sink, error) {
+		outputBase := path.Join(outputDir, fmt.Sprintf("%s_%d", baseFilename, fileNum))
+		log.Printf("Writing %s.dat", outputBase)
+		return sink.NewFileSink(outputBase)
+	}
+	if err := usb1608fsplus.RecordSeries(
+		context.Background(), ai, scansPerBuffer, buffersPerFile, numFiles, newSink, rtcTime,
+	); err != nil {
+		log.Fatalf("Error recording: %s", err)
 	}
-	c <- string(cmdOut)
 }